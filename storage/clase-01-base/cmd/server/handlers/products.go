@@ -9,6 +9,24 @@ import (
 	"strconv"
 )
 
+// parseFloatParam parses a query param as a float64, returning 0 if empty
+func parseFloatParam(value string) (f float64, err error) {
+	if value == "" {
+		return
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseIntParam parses a query param as an int, returning 0 if empty
+func parseIntParam(value string) (i int, err error) {
+	if value == "" {
+		return
+	}
+
+	return strconv.Atoi(value)
+}
+
 // NewControllerProduct returns new ControllerProduct
 func NewControllerProduct(storage storage.StorageProduct) *ControllerProduct {
 	return &ControllerProduct{storage: storage}
@@ -88,13 +106,149 @@ func (c *ControllerProduct) GetOne() http.HandlerFunc {
 	}
 }
 
-// Store stores product
-type RequestProductStore struct {
+// defaultListLimit is applied when the client omits ?limit=, and
+// maxListLimit caps it, so GET /products can't serialize the whole table
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// List returns products matching query params, paginated
+type RequestProductList struct {
+	MinPrice float64	`json:"min_price" validate:"gte=0"`
+	MaxPrice float64	`json:"max_price" validate:"gte=0"`
+	MinCount int		`json:"min_count" validate:"gte=0"`
+	Limit    int		`json:"limit"     validate:"gte=0"`
+	Offset   int		`json:"offset"    validate:"gte=0"`
+}
+type ResponseProductList struct {
 	Name    string	`json:"name"`
 	Type	string	`json:"type"`
 	Count	int		`json:"count"`
 	Price	float64	`json:"price"`
 }
+type ResponseBodyList struct {
+	Message string					`json:"message"`
+	Data    []*ResponseProductList	`json:"data"`
+	Total   int						`json:"total"`
+	Error   bool					`json:"error"`
+}
+func (c *ControllerProduct) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		query := r.URL.Query()
+
+		minPrice, err := parseFloatParam(query.Get("min_price"))
+		if err != nil {
+			code := http.StatusBadRequest
+			body := &ResponseBody{Message: "min_price must be a number", Data: nil, Error: true}
+
+			response.JSON(w, code, body)
+			return
+		}
+		maxPrice, err := parseFloatParam(query.Get("max_price"))
+		if err != nil {
+			code := http.StatusBadRequest
+			body := &ResponseBody{Message: "max_price must be a number", Data: nil, Error: true}
+
+			response.JSON(w, code, body)
+			return
+		}
+		minCount, err := parseIntParam(query.Get("min_count"))
+		if err != nil {
+			code := http.StatusBadRequest
+			body := &ResponseBody{Message: "min_count must be int", Data: nil, Error: true}
+
+			response.JSON(w, code, body)
+			return
+		}
+		limit, err := parseIntParam(query.Get("limit"))
+		if err != nil {
+			code := http.StatusBadRequest
+			body := &ResponseBody{Message: "limit must be int", Data: nil, Error: true}
+
+			response.JSON(w, code, body)
+			return
+		}
+		offset, err := parseIntParam(query.Get("offset"))
+		if err != nil {
+			code := http.StatusBadRequest
+			body := &ResponseBody{Message: "offset must be int", Data: nil, Error: true}
+
+			response.JSON(w, code, body)
+			return
+		}
+
+		switch {
+		case limit <= 0:
+			limit = defaultListLimit
+		case limit > maxListLimit:
+			limit = maxListLimit
+		}
+
+		req := RequestProductList{
+			MinPrice: minPrice,
+			MaxPrice: maxPrice,
+			MinCount: minCount,
+			Limit:    limit,
+			Offset:   offset,
+		}
+		if err := validate.Struct(req); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+
+		criteria := storage.SearchCriteria{
+			Name:     query.Get("name"),
+			Type:     query.Get("type"),
+			MinPrice: minPrice,
+			MaxPrice: maxPrice,
+			MinCount: minCount,
+			Sort:     query.Get("sort"),
+			Limit:    limit,
+			Offset:   offset,
+		}
+
+		// process
+		products, total, err := c.storage.Search(criteria)
+		if err != nil {
+			code := http.StatusInternalServerError
+			body := &ResponseBody{Message: "internal error", Data: nil, Error: true}
+
+			response.JSON(w, code, body)
+			return
+		}
+
+		// response
+		data := make([]*ResponseProductList, 0, len(products))
+		for _, product := range products {
+			data = append(data, &ResponseProductList{	// serialization
+				Name:   product.Name,
+				Type:	product.Type,
+				Count:	product.Count,
+				Price:	product.Price,
+			})
+		}
+
+		code := http.StatusOK
+		body := &ResponseBodyList{
+			Message: "success",
+			Data:    data,
+			Total:   total,
+			Error:   false,
+		}
+
+		response.JSON(w, code, body)
+	}
+}
+
+// Store stores product
+type RequestProductStore struct {
+	Name    string	`json:"name"  validate:"required,min=1"`
+	Type	string	`json:"type"  validate:"required,min=1"`
+	Count	int		`json:"count" validate:"gt=0"`
+	Price	float64	`json:"price" validate:"gt=0"`
+}
 type ResponseProductStore struct {
 	Name    string	`json:"name"`
 	Type	string	`json:"type"`
@@ -118,6 +272,10 @@ func (c *ControllerProduct) Store() http.HandlerFunc {
 			response.JSON(w, code, body)
 			return
 		}
+		if err := validate.Struct(req); err != nil {
+			writeValidationError(w, err)
+			return
+		}
 
 		// process
 		// -> deserialization
@@ -160,12 +318,12 @@ func (c *ControllerProduct) Store() http.HandlerFunc {
 	}
 }
 
-// Update updates product
+// Update replaces a product entirely. All fields are required
 type RequestProductUpdate struct {
-	Name    string	`json:"name"`
-	Type	string	`json:"type"`
-	Count	int		`json:"count"`
-	Price	float64	`json:"price"`
+	Name    string	`json:"name"  validate:"required,min=1"`
+	Type	string	`json:"type"  validate:"required,min=1"`
+	Count	int		`json:"count" validate:"gt=0"`
+	Price	float64	`json:"price" validate:"gt=0"`
 }
 type ResponseProductUpdate struct {
 	Name    string	`json:"name"`
@@ -198,33 +356,107 @@ func (c *ControllerProduct) Update() http.HandlerFunc {
 			return
 		}
 
+		var req RequestProductUpdate
+		err = request.JSON(r, &req)
+		if err != nil {
+			code := http.StatusBadRequest
+			body := &ResponseBody{Message: "invalid json", Data: nil, Error: true}
+
+			response.JSON(w, code, body)
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+
 		// process
-		// -> get searched product by id
-		pr, err := c.storage.GetOne(id)
+		// -> deserialization
+		product := &storage.Product{
+			ID:		id,
+			Name:   req.Name,
+			Type:	req.Type,
+			Count:	req.Count,
+			Price:	req.Price,
+		}
+		err = c.storage.Update(product)
 		if err != nil {
-			var code int; var body *ResponseBodyUpdate
+			var code int; var body *ResponseBody
 			switch {
 			case errors.Is(err, storage.ErrStorageProductNotFound):
 				code = http.StatusNotFound
-				body = &ResponseBodyUpdate{Message: "product not found", Data: nil, Error: true}
+				body = &ResponseBody{Message: "product not found", Data: nil, Error: true}
+			case errors.Is(err, storage.ErrStorageProductNotUnique):
+				code = http.StatusBadRequest
+				body = &ResponseBody{Message: "product not unique", Data: nil, Error: true}
 			default:
 				code = http.StatusInternalServerError
-				body = &ResponseBodyUpdate{Message: "internal error", Data: nil, Error: true}
+				body = &ResponseBody{Message: "internal error", Data: nil, Error: true}
 			}
 
 			response.JSON(w, code, body)
 			return
 		}
-		// -- serialization
-		product := &RequestProductUpdate{
-			Name:   pr.Name,
-			Type:	pr.Type,
-			Count:	pr.Count,
-			Price:	pr.Price,
+
+		// response
+		code := http.StatusOK
+		body := &ResponseBodyUpdate{
+			Message: "success",
+			Data: &ResponseProductUpdate{	// serialization
+				Name:   product.Name,
+				Type:	product.Type,
+				Count:	product.Count,
+				Price:	product.Price,
+			},
+			Error: false,
 		}
 
-		// -> patch product to RequestProductUpdate(filled with original data)
-		err = request.JSON(r, product)
+		response.JSON(w, code, body)
+	}
+}
+
+// Patch sparsely updates product, leaving fields omitted from the request
+// body untouched
+type RequestProductPatch struct {
+	Name  *string  `json:"name"  validate:"omitempty,min=1"`
+	Type  *string  `json:"type"  validate:"omitempty,min=1"`
+	Count *int     `json:"count" validate:"omitempty,gt=0"`
+	Price *float64 `json:"price" validate:"omitempty,gt=0"`
+}
+type ResponseProductPatch struct {
+	Name    string	`json:"name"`
+	Type	string	`json:"type"`
+	Count	int		`json:"count"`
+	Price	float64	`json:"price"`
+}
+type ResponseBodyPatch struct {
+	Message string					`json:"message"`
+	Data    *ResponseProductPatch	`json:"data"`
+	Error   bool					`json:"error"`
+}
+func (c *ControllerProduct) Patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		idParam, err := request.PathLastParam(r)
+		if err != nil {
+			code := http.StatusBadRequest
+			body := &ResponseBody{Message: "invalid path param", Data: nil, Error: true}
+
+			response.JSON(w, code, body)
+			return
+		}
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			code := http.StatusBadRequest
+			body := &ResponseBody{Message: "parameter must be int", Data: nil, Error: true}
+
+			response.JSON(w, code, body)
+			return
+		}
+
+		var req RequestProductPatch
+		err = request.JSON(r, &req)
 		if err != nil {
 			code := http.StatusBadRequest
 			body := &ResponseBody{Message: "invalid json", Data: nil, Error: true}
@@ -232,16 +464,19 @@ func (c *ControllerProduct) Update() http.HandlerFunc {
 			response.JSON(w, code, body)
 			return
 		}
-		// -- deserialization
-		prUpdate := &storage.Product{
-			ID:		id,
-			Name:   product.Name,
-			Type:	product.Type,
-			Count:	product.Count,
-			Price:	product.Price,
+		if err := validate.Struct(req); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+
+		// process
+		patch := storage.ProductPatch{
+			Name:  req.Name,
+			Type:  req.Type,
+			Count: req.Count,
+			Price: req.Price,
 		}
-		// -- update product
-		err = c.storage.Update(prUpdate)
+		product, err := c.storage.Patch(id, patch)
 		if err != nil {
 			var code int; var body *ResponseBody
 			switch {
@@ -262,13 +497,91 @@ func (c *ControllerProduct) Update() http.HandlerFunc {
 
 		// response
 		code := http.StatusOK
-		body := &ResponseBodyUpdate{
+		body := &ResponseBodyPatch{
 			Message: "success",
-			Data: &ResponseProductUpdate{	// serialization
-				Name:   prUpdate.Name,
-				Type:	prUpdate.Type,
-				Count:	prUpdate.Count,
-				Price:	prUpdate.Price,
+			Data: &ResponseProductPatch{	// serialization
+				Name:   product.Name,
+				Type:	product.Type,
+				Count:	product.Count,
+				Price:	product.Price,
+			},
+			Error: false,
+		}
+
+		response.JSON(w, code, body)
+	}
+}
+
+// Buy decrements a product's stock by qty
+type RequestProductBuy struct {
+	Qty int `json:"qty" validate:"required,gt=0"`
+}
+type ResponseProductBuy struct {
+	Name    string	`json:"name"`
+	Type	string	`json:"type"`
+	Count	int		`json:"count"`
+	Price	float64	`json:"price"`
+}
+type ResponseBodyBuy struct {
+	Message string				`json:"message"`
+	Data    *ResponseProductBuy	`json:"data"`
+	Error   bool				`json:"error"`
+}
+func (c *ControllerProduct) Buy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			code := http.StatusBadRequest
+			body := &ResponseBody{Message: "parameter must be int", Data: nil, Error: true}
+
+			response.JSON(w, code, body)
+			return
+		}
+
+		var req RequestProductBuy
+		err = request.JSON(r, &req)
+		if err != nil {
+			code := http.StatusBadRequest
+			body := &ResponseBody{Message: "invalid json", Data: nil, Error: true}
+
+			response.JSON(w, code, body)
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+
+		// process
+		product, err := c.storage.Buy(id, req.Qty)
+		if err != nil {
+			var code int; var body *ResponseBody
+			switch {
+			case errors.Is(err, storage.ErrStorageProductNotFound):
+				code = http.StatusNotFound
+				body = &ResponseBody{Message: "product not found", Data: nil, Error: true}
+			case errors.Is(err, storage.ErrStorageProductInsufficientStock):
+				code = http.StatusConflict
+				body = &ResponseBody{Message: "insufficient stock", Data: nil, Error: true}
+			default:
+				code = http.StatusInternalServerError
+				body = &ResponseBody{Message: "internal error", Data: nil, Error: true}
+			}
+
+			response.JSON(w, code, body)
+			return
+		}
+
+		// response
+		code := http.StatusOK
+		body := &ResponseBodyBuy{
+			Message: "success",
+			Data: &ResponseProductBuy{	// serialization
+				Name:   product.Name,
+				Type:	product.Type,
+				Count:	product.Count,
+				Price:	product.Price,
 			},
 			Error: false,
 		}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"app/internal/products/storage"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestServer wires up ControllerProduct against a fresh in-memory
+// backend, exactly like main.go's serveHTTP, so handler tests don't need a
+// database
+func newTestServer() *httptest.Server {
+	controller := NewControllerProduct(storage.NewImplStorageProductMemory())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /products", controller.List())
+	mux.HandleFunc("GET /products/{id}", controller.GetOne())
+	mux.HandleFunc("POST /products", controller.Store())
+	mux.HandleFunc("PUT /products/{id}", controller.Update())
+	mux.HandleFunc("PATCH /products/{id}", controller.Patch())
+	mux.HandleFunc("POST /products/{id}/buy", controller.Buy())
+	mux.HandleFunc("DELETE /products/{id}", controller.Delete())
+
+	return httptest.NewServer(mux)
+}
+
+func doJSON(t *testing.T, method, url string, body any) (*http.Response, map[string]any) {
+	t.Helper()
+
+	var reqBody *bytes.Buffer
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("json.Marshal(%+v) error = %v", body, err)
+		}
+		reqBody = bytes.NewBuffer(b)
+	} else {
+		reqBody = bytes.NewBuffer(nil)
+	}
+
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	return resp, decoded
+}
+
+func TestControllerProductStoreValidation(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	resp, body := doJSON(t, http.MethodPost, srv.URL+"/products", map[string]any{
+		"name":  "",
+		"type":  "peripheral",
+		"count": 10,
+		"price": 49.99,
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Store() with empty name status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if body["error"] != true {
+		t.Fatalf("Store() with empty name body = %+v, want error = true", body)
+	}
+}
+
+func TestControllerProductStoreUpdatePatchBuy(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	resp, body := doJSON(t, http.MethodPost, srv.URL+"/products", map[string]any{
+		"name":  "keyboard",
+		"type":  "peripheral",
+		"count": 10,
+		"price": 49.99,
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Store() status = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+
+	// the id isn't in the Store or List response bodies; the in-memory
+	// backend numbers ids from 1, so check there is exactly one product and
+	// address it as id 1 below
+	_, listBody := doJSON(t, http.MethodGet, srv.URL+"/products", nil)
+	data, _ := listBody["data"].([]any)
+	if len(data) != 1 {
+		t.Fatalf("List() after Store() = %+v, want exactly one product", listBody)
+	}
+
+	resp, listAgain := doJSON(t, http.MethodGet, srv.URL+"/products?name=keyboard", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("List(name=keyboard) status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if listAgain["total"].(float64) != 1 {
+		t.Fatalf("List(name=keyboard) total = %v, want 1", listAgain["total"])
+	}
+
+	// Update a product that doesn't exist -> 404
+	resp, body = doJSON(t, http.MethodPut, srv.URL+"/products/999999", map[string]any{
+		"name":  "keyboard",
+		"type":  "peripheral",
+		"count": 5,
+		"price": 39.99,
+	})
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Update() of a missing id status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	// Patch with a bad value (count <= 0) -> 400
+	resp, body = doJSON(t, http.MethodPatch, srv.URL+"/products/1", map[string]any{
+		"count": -1,
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Patch() with count <= 0 status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	// Buy more than what's in stock -> 409
+	resp, body = doJSON(t, http.MethodPost, srv.URL+"/products/1/buy", map[string]any{
+		"qty": 100,
+	})
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Buy() with insufficient stock status = %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	// Buy within stock -> 200
+	resp, body = doJSON(t, http.MethodPost, srv.URL+"/products/1/buy", map[string]any{
+		"qty": 4,
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Buy() status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	data2, _ := body["data"].(map[string]any)
+	if data2["count"].(float64) != 6 {
+		t.Fatalf("Buy() data.count = %v, want 6", data2["count"])
+	}
+}
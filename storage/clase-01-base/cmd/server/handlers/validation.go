@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"app/pkg/web/response"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate is the package-wide validator instance, shared by every handler
+var validate = validator.New()
+
+func init() {
+	// report the request's own json field names (e.g. "min_price") instead
+	// of the Go struct field names (e.g. "MinPrice")
+	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+}
+
+// FieldError describes one field that failed validation
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Value any    `json:"value"`
+}
+
+// ResponseBodyValidation is the body returned when request validation fails
+type ResponseBodyValidation struct {
+	Message string       `json:"message"`
+	Error   bool         `json:"error"`
+	Fields  []FieldError `json:"fields"`
+}
+
+// getValidationErrors converts err into the FieldError list it carries, or
+// nil if err isn't a validator.ValidationErrors
+func getValidationErrors(err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		fields = append(fields, FieldError{Field: fe.Field(), Rule: fe.Tag(), Value: fe.Value()})
+	}
+
+	return fields
+}
+
+// writeValidationError writes a 400 response body listing the field errors
+// carried by err
+func writeValidationError(w http.ResponseWriter, err error) {
+	code := http.StatusBadRequest
+	body := &ResponseBodyValidation{
+		Message: "validation failed",
+		Error:   true,
+		Fields:  getValidationErrors(err),
+	}
+
+	response.JSON(w, code, body)
+}
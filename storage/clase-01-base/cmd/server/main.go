@@ -0,0 +1,74 @@
+package main
+
+import (
+	"app/cmd/server/handlers"
+	grpcserver "app/internal/products/grpc"
+	"app/internal/products/grpc/pb"
+	"app/internal/products/storage"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	protocol := flag.String("protocol", "http", "protocol to serve: http or grpc")
+	addr := flag.String("addr", ":8080", "address to listen on")
+	storageType := flag.String("storage", "mysql", "storage backend: mysql, postgres or memory")
+	dsn := flag.String("dsn", "", "storage DSN")
+	flag.Parse()
+
+	storageProduct, err := storage.NewStorageProduct(storage.StorageConfig{
+		Type: storage.StorageType(*storageType),
+		DSN:  *dsn,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch *protocol {
+	case "grpc":
+		serveGRPC(*addr, storageProduct)
+	default:
+		serveHTTP(*addr, storageProduct)
+	}
+}
+
+// serveHTTP starts the HTTP server, exposing ControllerProduct
+func serveHTTP(addr string, storageProduct storage.StorageProduct) {
+	controller := handlers.NewControllerProduct(storageProduct)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /products", controller.List())
+	mux.HandleFunc("GET /products/{id}", controller.GetOne())
+	mux.HandleFunc("POST /products", controller.Store())
+	mux.HandleFunc("PUT /products/{id}", controller.Update())
+	mux.HandleFunc("PATCH /products/{id}", controller.Patch())
+	mux.HandleFunc("POST /products/{id}/buy", controller.Buy())
+	mux.HandleFunc("DELETE /products/{id}", controller.Delete())
+
+	log.Printf("listening on %s (http)", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// serveGRPC starts the gRPC server, exposing ServerProduct
+func serveGRPC(addr string, storageProduct storage.StorageProduct) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	pb.RegisterProductServiceServer(server, grpcserver.NewServerProduct(storageProduct))
+
+	log.Printf("listening on %s (grpc)", addr)
+	if err := server.Serve(listener); err != nil {
+		log.Fatal(err)
+	}
+}
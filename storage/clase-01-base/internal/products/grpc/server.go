@@ -0,0 +1,190 @@
+package grpc
+
+import (
+	"app/internal/products/grpc/pb"
+	"app/internal/products/storage"
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewServerProduct returns new ServerProduct
+func NewServerProduct(storage storage.StorageProduct) *ServerProduct {
+	return &ServerProduct{storage: storage}
+}
+
+// ServerProduct is a gRPC server for products, delegating to the same
+// storage.StorageProduct used by the HTTP ControllerProduct
+type ServerProduct struct {
+	pb.UnimplementedProductServiceServer
+
+	// storage is a storage for products
+	storage storage.StorageProduct
+}
+
+// toPb serializes a storage.Product into its pb representation
+func toPb(p *storage.Product) *pb.Product {
+	return &pb.Product{
+		Id:    int32(p.ID),
+		Name:  p.Name,
+		Type:  p.Type,
+		Count: int32(p.Count),
+		Price: p.Price,
+	}
+}
+
+// toStorageErr maps a sentinel storage error onto a gRPC status error
+func toStorageErr(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrStorageProductNotFound):
+		return status.Error(codes.NotFound, "product not found")
+	case errors.Is(err, storage.ErrStorageProductNotUnique):
+		return status.Error(codes.AlreadyExists, "product not unique")
+	case errors.Is(err, storage.ErrStorageProductInsufficientStock):
+		return status.Error(codes.FailedPrecondition, "insufficient stock")
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// int32Ptr converts a possibly-nil *int32 into a *int, for use in
+// storage.ProductPatch
+func int32Ptr(i *int32) *int {
+	if i == nil {
+		return nil
+	}
+	v := int(*i)
+	return &v
+}
+
+// GetOne returns one product by id
+func (s *ServerProduct) GetOne(ctx context.Context, req *pb.GetOneRequest) (res *pb.GetOneResponse, err error) {
+	product, err := s.storage.GetOne(int(req.Id))
+	if err != nil {
+		err = toStorageErr(err)
+		return
+	}
+
+	res = &pb.GetOneResponse{Product: toPb(product)}
+	return
+}
+
+// Store stores product
+func (s *ServerProduct) Store(ctx context.Context, req *pb.StoreRequest) (res *pb.StoreResponse, err error) {
+	if req.Product == nil {
+		err = status.Error(codes.InvalidArgument, "product is required")
+		return
+	}
+
+	product := &storage.Product{
+		Name:  req.Product.Name,
+		Type:  req.Product.Type,
+		Count: int(req.Product.Count),
+		Price: req.Product.Price,
+	}
+
+	err = s.storage.Store(product)
+	if err != nil {
+		err = toStorageErr(err)
+		return
+	}
+
+	res = &pb.StoreResponse{Product: toPb(product)}
+	return
+}
+
+// Update updates product
+func (s *ServerProduct) Update(ctx context.Context, req *pb.UpdateRequest) (res *pb.UpdateResponse, err error) {
+	if req.Product == nil {
+		err = status.Error(codes.InvalidArgument, "product is required")
+		return
+	}
+
+	product := &storage.Product{
+		ID:    int(req.Product.Id),
+		Name:  req.Product.Name,
+		Type:  req.Product.Type,
+		Count: int(req.Product.Count),
+		Price: req.Product.Price,
+	}
+
+	err = s.storage.Update(product)
+	if err != nil {
+		err = toStorageErr(err)
+		return
+	}
+
+	res = &pb.UpdateResponse{Product: toPb(product)}
+	return
+}
+
+// Patch applies a sparse update to a product, leaving unset fields untouched
+func (s *ServerProduct) Patch(ctx context.Context, req *pb.PatchRequest) (res *pb.PatchResponse, err error) {
+	patch := storage.ProductPatch{
+		Name:  req.Name,
+		Type:  req.Type,
+		Count: int32Ptr(req.Count),
+		Price: req.Price,
+	}
+
+	product, err := s.storage.Patch(int(req.Id), patch)
+	if err != nil {
+		err = toStorageErr(err)
+		return
+	}
+
+	res = &pb.PatchResponse{Product: toPb(product)}
+	return
+}
+
+// Buy decrements a product's stock by qty, failing if there isn't enough of
+// it left
+func (s *ServerProduct) Buy(ctx context.Context, req *pb.BuyRequest) (res *pb.BuyResponse, err error) {
+	product, err := s.storage.Buy(int(req.Id), int(req.Qty))
+	if err != nil {
+		err = toStorageErr(err)
+		return
+	}
+
+	res = &pb.BuyResponse{Product: toPb(product)}
+	return
+}
+
+// Delete deletes product by id
+func (s *ServerProduct) Delete(ctx context.Context, req *pb.DeleteRequest) (res *pb.DeleteResponse, err error) {
+	err = s.storage.Delete(int(req.Id))
+	if err != nil {
+		err = toStorageErr(err)
+		return
+	}
+
+	res = &pb.DeleteResponse{}
+	return
+}
+
+// List streams every product matching no filter, page by page
+func (s *ServerProduct) List(req *pb.ListRequest, stream pb.ProductService_ListServer) (err error) {
+	const pageSize = 100
+
+	offset := 0
+	for {
+		// sort by id so pages stay stable as they're fetched one after another
+		products, _, err := s.storage.Search(storage.SearchCriteria{Sort: "id", Limit: pageSize, Offset: offset})
+		if err != nil {
+			return toStorageErr(err)
+		}
+
+		for _, product := range products {
+			if err := stream.Send(&pb.GetOneResponse{Product: toPb(product)}); err != nil {
+				return err
+			}
+		}
+
+		if len(products) < pageSize {
+			return nil
+		}
+		offset += pageSize
+	}
+}
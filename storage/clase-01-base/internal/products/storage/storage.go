@@ -1,6 +1,9 @@
 package storage
 
-import "errors"
+import (
+	"database/sql"
+	"errors"
+)
 
 // Product is a product model
 type Product struct {
@@ -11,17 +14,55 @@ type Product struct {
 	Price	float64
 }
 
+// SearchCriteria is a set of filters, sort and pagination options for Search
+type SearchCriteria struct {
+	Name     string
+	Type     string
+	MinPrice float64
+	MaxPrice float64
+	MinCount int
+
+	// Sort is a comma-separated list of columns, e.g. "price,-name".
+	// A leading "-" sorts that column descending.
+	Sort string
+
+	// Limit and Offset page the result set. Limit <= 0 means no limit.
+	Limit  int
+	Offset int
+}
+
+// ProductPatch is a sparse update for a product: fields left nil are not
+// touched
+type ProductPatch struct {
+	Name  *string  `json:"name"`
+	Type  *string  `json:"type"`
+	Count *int     `json:"count"`
+	Price *float64 `json:"price"`
+}
+
 // StorageProduct is an interface for product storage
 type StorageProduct interface {
 	// GetOne returns one product by id
 	GetOne(id int) (p *Product, err error)
 
+	// Search returns products matching criteria, along with the total count
+	// ignoring pagination (for building paginated responses)
+	Search(criteria SearchCriteria) (p []*Product, total int, err error)
+
 	// Store stores product
 	Store(p *Product) (err error)
 
-	// Update updates product
+	// Update replaces a product entirely
 	Update(p *Product) (err error)
 
+	// Patch applies a sparse update to a product, leaving nil fields in
+	// patch untouched, and returns the product as stored after the patch
+	Patch(id int, patch ProductPatch) (p *Product, err error)
+
+	// Buy decrements a product's stock by qty, failing if there isn't
+	// enough of it left, and returns the product as stored after the buy
+	Buy(id int, qty int) (p *Product, err error)
+
 	// Delete deletes product by id
 	Delete(id int) (err error)
 }
@@ -30,4 +71,32 @@ var (
 	ErrStorageProductInternal = errors.New("internal storage product error")
 	ErrStorageProductNotFound = errors.New("storage product not found")
 	ErrStorageProductNotUnique = errors.New("storage product not unique")
-)
\ No newline at end of file
+	ErrStorageProductInsufficientStock = errors.New("storage product insufficient stock")
+)
+
+// nullString converts a possibly-nil pointer into a sql.NullString, for use
+// in Patch queries
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+// nullInt32 converts a possibly-nil pointer into a sql.NullInt32, for use in
+// Patch queries
+func nullInt32(i *int) sql.NullInt32 {
+	if i == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(*i), Valid: true}
+}
+
+// nullFloat64 converts a possibly-nil pointer into a sql.NullFloat64, for use
+// in Patch queries
+func nullFloat64(f *float64) sql.NullFloat64 {
+	if f == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *f, Valid: true}
+}
\ No newline at end of file
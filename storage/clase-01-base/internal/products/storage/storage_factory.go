@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// StorageType identifies which StorageProduct implementation to build
+type StorageType string
+
+const (
+	StorageTypeMySQL    StorageType = "mysql"
+	StorageTypePostgres StorageType = "postgres"
+	StorageTypeMemory   StorageType = "memory"
+)
+
+// StorageConfig configures the StorageProduct returned by NewStorageProduct
+type StorageConfig struct {
+	Type StorageType
+	// DSN is the data source name used to open the connection. Ignored for
+	// StorageTypeMemory
+	DSN string
+}
+
+// NewStorageProduct builds the StorageProduct implementation selected by
+// cfg.Type
+func NewStorageProduct(cfg StorageConfig) (s StorageProduct, err error) {
+	switch cfg.Type {
+	case StorageTypeMySQL:
+		var db *sql.DB
+		db, err = sql.Open("mysql", cfg.DSN)
+		if err != nil {
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+			return
+		}
+		s = NewImplStorageProductMySQL(db)
+
+	case StorageTypePostgres:
+		var db *sql.DB
+		db, err = sql.Open("postgres", cfg.DSN)
+		if err != nil {
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+			return
+		}
+		s = NewImplStorageProductPostgres(db)
+
+	case StorageTypeMemory:
+		s = NewImplStorageProductMemory()
+
+	default:
+		err = fmt.Errorf("%w. unknown storage type %q", ErrStorageProductInternal, cfg.Type)
+	}
+
+	return
+}
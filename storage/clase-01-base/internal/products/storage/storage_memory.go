@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NewImplStorageProductMemory returns new ImplStorageProductMemory
+func NewImplStorageProductMemory() *ImplStorageProductMemory {
+	return &ImplStorageProductMemory{db: make(map[int]*Product)}
+}
+
+// ImplStorageProductMemory is an in-memory implementation of StorageProduct,
+// for local development without a database
+type ImplStorageProductMemory struct {
+	mu     sync.Mutex
+	db     map[int]*Product
+	lastID int
+}
+
+// GetOne returns one product by id
+func (impl *ImplStorageProductMemory) GetOne(id int) (p *Product, err error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	product, ok := impl.db[id]
+	if !ok {
+		err = ErrStorageProductNotFound
+		return
+	}
+
+	cp := *product
+	p = &cp
+	return
+}
+
+// Search returns products matching criteria, along with the total count
+// ignoring pagination
+func (impl *ImplStorageProductMemory) Search(criteria SearchCriteria) (p []*Product, total int, err error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	// -> filter
+	var matches []*Product
+	for _, product := range impl.db {
+		if criteria.Name != "" && product.Name != criteria.Name {
+			continue
+		}
+		if criteria.Type != "" && product.Type != criteria.Type {
+			continue
+		}
+		if criteria.MinPrice != 0 && product.Price < criteria.MinPrice {
+			continue
+		}
+		if criteria.MaxPrice != 0 && product.Price > criteria.MaxPrice {
+			continue
+		}
+		if criteria.MinCount != 0 && product.Count < criteria.MinCount {
+			continue
+		}
+
+		cp := *product
+		matches = append(matches, &cp)
+	}
+	total = len(matches)
+
+	// -> sort from the whitelisted sort columns, applied in order
+	if criteria.Sort != "" {
+		fields := strings.Split(criteria.Sort, ",")
+		sort.SliceStable(matches, func(i, j int) bool {
+			for _, field := range fields {
+				direction := 1
+				if strings.HasPrefix(field, "-") {
+					direction = -1
+					field = field[1:]
+				}
+				if _, ok := searchSortColumns[field]; !ok {
+					continue
+				}
+
+				var less, greater bool
+				switch field {
+				case "id":
+					less, greater = matches[i].ID < matches[j].ID, matches[i].ID > matches[j].ID
+				case "name":
+					less, greater = matches[i].Name < matches[j].Name, matches[i].Name > matches[j].Name
+				case "type":
+					less, greater = matches[i].Type < matches[j].Type, matches[i].Type > matches[j].Type
+				case "count":
+					less, greater = matches[i].Count < matches[j].Count, matches[i].Count > matches[j].Count
+				case "price":
+					less, greater = matches[i].Price < matches[j].Price, matches[i].Price > matches[j].Price
+				}
+
+				if less {
+					return direction == 1
+				}
+				if greater {
+					return direction == -1
+				}
+			}
+			return false
+		})
+	}
+
+	// -> paginate
+	if criteria.Limit > 0 {
+		start := criteria.Offset
+		if start > len(matches) {
+			start = len(matches)
+		}
+		end := start + criteria.Limit
+		if end > len(matches) {
+			end = len(matches)
+		}
+		matches = matches[start:end]
+	}
+
+	p = matches
+	return
+}
+
+// Store stores product
+func (impl *ImplStorageProductMemory) Store(p *Product) (err error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	for _, product := range impl.db {
+		if product.Name == (*p).Name {
+			err = ErrStorageProductNotUnique
+			return
+		}
+	}
+
+	impl.lastID++
+	(*p).ID = impl.lastID
+
+	cp := *p
+	impl.db[(*p).ID] = &cp
+
+	return
+}
+
+// Update updates product
+func (impl *ImplStorageProductMemory) Update(p *Product) (err error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	if _, ok := impl.db[(*p).ID]; !ok {
+		err = ErrStorageProductNotFound
+		return
+	}
+
+	for id, product := range impl.db {
+		if id != (*p).ID && product.Name == (*p).Name {
+			err = ErrStorageProductNotUnique
+			return
+		}
+	}
+
+	cp := *p
+	impl.db[(*p).ID] = &cp
+
+	return
+}
+
+// Patch applies a sparse update to a product, leaving nil fields in patch
+// untouched
+func (impl *ImplStorageProductMemory) Patch(id int, patch ProductPatch) (p *Product, err error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	product, ok := impl.db[id]
+	if !ok {
+		err = ErrStorageProductNotFound
+		return
+	}
+
+	updated := *product
+	if patch.Name != nil {
+		updated.Name = *patch.Name
+	}
+	if patch.Type != nil {
+		updated.Type = *patch.Type
+	}
+	if patch.Count != nil {
+		updated.Count = *patch.Count
+	}
+	if patch.Price != nil {
+		updated.Price = *patch.Price
+	}
+
+	for otherID, other := range impl.db {
+		if otherID != id && other.Name == updated.Name {
+			err = ErrStorageProductNotUnique
+			return
+		}
+	}
+
+	impl.db[id] = &updated
+
+	cp := updated
+	p = &cp
+	return
+}
+
+// Buy decrements a product's stock by qty, failing if there isn't enough of
+// it left
+func (impl *ImplStorageProductMemory) Buy(id int, qty int) (p *Product, err error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	product, ok := impl.db[id]
+	if !ok {
+		err = ErrStorageProductNotFound
+		return
+	}
+
+	if product.Count < qty {
+		err = fmt.Errorf("%w. have %d, want %d", ErrStorageProductInsufficientStock, product.Count, qty)
+		return
+	}
+
+	product.Count -= qty
+
+	cp := *product
+	p = &cp
+	return
+}
+
+// Delete deletes product by id
+func (impl *ImplStorageProductMemory) Delete(id int) (err error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+
+	if _, ok := impl.db[id]; !ok {
+		err = ErrStorageProductNotFound
+		return
+	}
+
+	delete(impl.db, id)
+	return
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func seedMemory(t *testing.T, impl *ImplStorageProductMemory, products []*Product) {
+	t.Helper()
+	for _, p := range products {
+		cp := *p
+		if err := impl.Store(&cp); err != nil {
+			t.Fatalf("Store(%+v) error = %v", p, err)
+		}
+		p.ID = cp.ID
+	}
+}
+
+func TestImplStorageProductMemorySearchFilterSortPaginate(t *testing.T) {
+	impl := NewImplStorageProductMemory()
+
+	keyboard := &Product{Name: "keyboard", Type: "peripheral", Count: 10, Price: 49.99}
+	mouse := &Product{Name: "mouse", Type: "peripheral", Count: 30, Price: 19.99}
+	monitor := &Product{Name: "monitor", Type: "display", Count: 5, Price: 199.99}
+	seedMemory(t, impl, []*Product{keyboard, mouse, monitor})
+
+	// filter by type
+	found, total, err := impl.Search(SearchCriteria{Type: "peripheral"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if total != 2 || len(found) != 2 {
+		t.Fatalf("Search(type=peripheral) = %d results (total %d), want 2", len(found), total)
+	}
+
+	// sort by price ascending, paginate to the second page of 1
+	found, total, err = impl.Search(SearchCriteria{Sort: "price", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if total != 3 {
+		t.Fatalf("Search() total = %d, want 3 (pagination must not affect the total count)", total)
+	}
+	if len(found) != 1 || found[0].Name != "keyboard" {
+		t.Fatalf("Search(sort=price, limit=1, offset=1) = %+v, want [keyboard]", found)
+	}
+
+	// offset beyond the result set returns no rows, not an error
+	found, total, err = impl.Search(SearchCriteria{Limit: 10, Offset: 100})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if total != 3 || len(found) != 0 {
+		t.Fatalf("Search(offset=100) = %d results (total %d), want 0 results, total 3", len(found), total)
+	}
+}
+
+func TestImplStorageProductMemoryPatch(t *testing.T) {
+	impl := NewImplStorageProductMemory()
+
+	product := &Product{Name: "keyboard", Type: "peripheral", Count: 10, Price: 49.99}
+	seedMemory(t, impl, []*Product{product})
+
+	newPrice := 39.99
+	patched, err := impl.Patch(product.ID, ProductPatch{Price: &newPrice})
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if patched.Price != newPrice {
+		t.Fatalf("Patch() price = %v, want %v", patched.Price, newPrice)
+	}
+	if patched.Name != product.Name {
+		t.Fatalf("Patch() overwrote name = %q, want %q", patched.Name, product.Name)
+	}
+
+	if _, err := impl.Patch(product.ID+1, ProductPatch{Price: &newPrice}); !errors.Is(err, ErrStorageProductNotFound) {
+		t.Fatalf("Patch() of a missing id error = %v, want %v", err, ErrStorageProductNotFound)
+	}
+}
+
+func TestImplStorageProductMemoryBuy(t *testing.T) {
+	impl := NewImplStorageProductMemory()
+
+	product := &Product{Name: "keyboard", Type: "peripheral", Count: 10, Price: 49.99}
+	seedMemory(t, impl, []*Product{product})
+
+	bought, err := impl.Buy(product.ID, 4)
+	if err != nil {
+		t.Fatalf("Buy() error = %v", err)
+	}
+	if bought.Count != 6 {
+		t.Fatalf("Buy() count = %d, want 6", bought.Count)
+	}
+
+	if _, err := impl.Buy(product.ID, 100); !errors.Is(err, ErrStorageProductInsufficientStock) {
+		t.Fatalf("Buy() with insufficient stock error = %v, want %v", err, ErrStorageProductInsufficientStock)
+	}
+
+	if _, err := impl.Buy(product.ID+1, 1); !errors.Is(err, ErrStorageProductNotFound) {
+		t.Fatalf("Buy() of a missing id error = %v, want %v", err, ErrStorageProductNotFound)
+	}
+}
@@ -1,13 +1,43 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/go-sql-driver/mysql"
 )
 
+// searchSortColumns whitelists the columns that Search accepts in its Sort
+// criteria, mapping the public name to the actual SQL column
+var searchSortColumns = map[string]string{
+	"id":    "id",
+	"name":  "name",
+	"type":  "type",
+	"count": "count",
+	"price": "price",
+}
+
+// uniqueViolationMySQL reports whether err is a MySQL duplicate-key error
+func uniqueViolationMySQL(err error) bool {
+	errMySQL, ok := err.(*mysql.MySQLError)
+	return ok && errMySQL.Number == 1062
+}
+
+// existsByID reports whether a product with id exists. It is needed to tell
+// "no rows matched" from "no rows changed" apart: by default the MySQL
+// driver reports RowsAffected as rows whose values actually changed, not
+// rows matched by the WHERE clause, so a no-op UPDATE/PATCH also reports 0
+func (impl *ImplStorageProductMySQL) existsByID(id int) (exists bool, err error) {
+	err = impl.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = ?)", id).Scan(&exists)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+	}
+	return
+}
+
 // NewImplStorageProductMySQL returns new ImplStorageProductMySQL
 func NewImplStorageProductMySQL(db *sql.DB) *ImplStorageProductMySQL {
 	return &ImplStorageProductMySQL{db: db}
@@ -81,6 +111,142 @@ func (impl *ImplStorageProductMySQL) GetOne(id int) (p *Product, err error) {
 	return
 }
 
+// Search returns products matching criteria, along with the total count
+// ignoring pagination
+func (impl *ImplStorageProductMySQL) Search(criteria SearchCriteria) (p []*Product, total int, err error) {
+	// build WHERE clause shared by the select and the count query
+	var conditions []string
+	var args []any
+	if criteria.Name != "" {
+		conditions = append(conditions, "name = ?")
+		args = append(args, criteria.Name)
+	}
+	if criteria.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, criteria.Type)
+	}
+	if criteria.MinPrice != 0 {
+		conditions = append(conditions, "price >= ?")
+		args = append(args, criteria.MinPrice)
+	}
+	if criteria.MaxPrice != 0 {
+		conditions = append(conditions, "price <= ?")
+		args = append(args, criteria.MaxPrice)
+	}
+	if criteria.MinCount != 0 {
+		conditions = append(conditions, "count >= ?")
+		args = append(args, criteria.MinCount)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// -> count total rows matching the filters, ignoring pagination
+	countQuery := "SELECT COUNT(*) FROM products" + where
+
+	var countStmt *sql.Stmt
+	countStmt, err = impl.db.Prepare(countQuery)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer countStmt.Close()
+
+	err = countStmt.QueryRow(args...).Scan(&total)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+
+	// -> build ORDER BY from the whitelisted sort columns
+	orderBy := ""
+	if criteria.Sort != "" {
+		var orderings []string
+		for _, field := range strings.Split(criteria.Sort, ",") {
+			direction := "ASC"
+			if strings.HasPrefix(field, "-") {
+				direction = "DESC"
+				field = field[1:]
+			}
+
+			column, ok := searchSortColumns[field]
+			if !ok {
+				continue
+			}
+			orderings = append(orderings, column+" "+direction)
+		}
+		if len(orderings) > 0 {
+			orderBy = " ORDER BY " + strings.Join(orderings, ", ")
+		}
+	}
+
+	// -> pagination
+	limitClause := ""
+	if criteria.Limit > 0 {
+		limitClause = " LIMIT ? OFFSET ?"
+		args = append(args, criteria.Limit, criteria.Offset)
+	}
+
+	// query
+	query := "SELECT id, name, type, count, price FROM products" + where + orderBy + limitClause
+
+	// prepare statement
+	var stmt *sql.Stmt
+	stmt, err = impl.db.Prepare(query)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer stmt.Close()
+
+	// execute query
+	var rows *sql.Rows
+	rows, err = stmt.Query(args...)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer rows.Close()
+
+	// scan rows
+	for rows.Next() {
+		var product ProductMySQL
+		err = rows.Scan(&product.ID, &product.Name, &product.Type, &product.Count, &product.Price)
+		if err != nil {
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+			return
+		}
+
+		// serialization
+		pr := new(Product)
+		if product.ID.Valid {
+			(*pr).ID = int(product.ID.Int32)
+		}
+		if product.Name.Valid {
+			(*pr).Name = product.Name.String
+		}
+		if product.Type.Valid {
+			(*pr).Type = product.Type.String
+		}
+		if product.Count.Valid {
+			(*pr).Count = int(product.Count.Int32)
+		}
+		if product.Price.Valid {
+			(*pr).Price = product.Price.Float64
+		}
+
+		p = append(p, pr)
+	}
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+
+	return
+}
+
 // Store stores product
 func (impl *ImplStorageProductMySQL) Store(p *Product) (err error) {
 	// deserialize
@@ -117,18 +283,11 @@ func (impl *ImplStorageProductMySQL) Store(p *Product) (err error) {
 	// execute query
 	result, err := stmt.Exec(product.Name, product.Type, product.Count, product.Price)
 	if err != nil {
-		errMySQL, ok := err.(*mysql.MySQLError); if ok {
-			switch errMySQL.Number {
-			case 1062:
-				err = fmt.Errorf("%w. %v", ErrStorageProductNotUnique, err)
-			default:
-				err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
-			}
-
-			return
+		if uniqueViolationMySQL(err) {
+			err = fmt.Errorf("%w. %v", ErrStorageProductNotUnique, err)
+		} else {
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
 		}
-
-		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
 		return
 	}
 
@@ -203,10 +362,133 @@ func (impl *ImplStorageProductMySQL) Update(p *Product) (err error) {
 		return
 	}
 
-	if rowsAffected != 1 {
-		err = fmt.Errorf("%w. %s", ErrStorageProductInternal, "rows affected != 1")
+	if rowsAffected == 0 {
+		var exists bool
+		exists, err = impl.existsByID((*p).ID)
+		if err != nil {
+			return
+		}
+		if !exists {
+			err = fmt.Errorf("%w. %s", ErrStorageProductNotFound, "no rows affected")
+			return
+		}
+		// row exists; the replace was a no-op because the values already matched
+	}
+
+	return
+}
+
+// Patch applies a sparse update to a product, leaving nil fields in patch
+// untouched
+func (impl *ImplStorageProductMySQL) Patch(id int, patch ProductPatch) (p *Product, err error) {
+	// query
+	query := "UPDATE products SET name = COALESCE(?, name), type = COALESCE(?, type), count = COALESCE(?, count), price = COALESCE(?, price) WHERE id = ?"
+
+	// prepare statement
+	var stmt *sql.Stmt
+	stmt, err = impl.db.Prepare(query)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer stmt.Close()
+
+	// execute query
+	result, err := stmt.Exec(nullString(patch.Name), nullString(patch.Type), nullInt32(patch.Count), nullFloat64(patch.Price), id)
+	if err != nil {
+		if uniqueViolationMySQL(err) {
+			err = fmt.Errorf("%w. %v", ErrStorageProductNotUnique, err)
+		} else {
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		}
+		return
+	}
+
+	// check rows affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	if rowsAffected == 0 {
+		var exists bool
+		exists, err = impl.existsByID(id)
+		if err != nil {
+			return
+		}
+		if !exists {
+			err = fmt.Errorf("%w. %s", ErrStorageProductNotFound, "no rows affected")
+			return
+		}
+		// row exists; the patch was a no-op because the values already matched
+	}
+
+	// GetOne doesn't fill in ID, so set it from the id that was patched
+	p, err = impl.GetOne(id)
+	if err != nil {
 		return
 	}
+	(*p).ID = id
+	return
+}
+
+// Buy decrements a product's stock by qty, failing if there isn't enough of
+// it left
+func (impl *ImplStorageProductMySQL) Buy(id int, qty int) (p *Product, err error) {
+	// begin transaction
+	var tx *sql.Tx
+	tx, err = impl.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer tx.Rollback()
+
+	// -> lock the row for the duration of the transaction
+	var product ProductMySQL
+	row := tx.QueryRow("SELECT id, name, type, count, price FROM products WHERE id = ? FOR UPDATE", id)
+	err = row.Scan(&product.ID, &product.Name, &product.Type, &product.Count, &product.Price)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = fmt.Errorf("%w. %v", ErrStorageProductNotFound, err)
+		default:
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		}
+		return
+	}
+
+	currentCount := int(product.Count.Int32)
+	if currentCount < qty {
+		err = fmt.Errorf("%w. have %d, want %d", ErrStorageProductInsufficientStock, currentCount, qty)
+		return
+	}
+
+	// -> decrement stock
+	_, err = tx.Exec("UPDATE products SET count = count - ? WHERE id = ?", qty, id)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+
+	// serialization
+	p = new(Product)
+	if product.Name.Valid {
+		(*p).Name = product.Name.String
+	}
+	if product.Type.Valid {
+		(*p).Type = product.Type.String
+	}
+	if product.Price.Valid {
+		(*p).Price = product.Price.Float64
+	}
+	(*p).ID = id
+	(*p).Count = currentCount - qty
 
 	return
 }
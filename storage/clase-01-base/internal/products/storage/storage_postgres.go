@@ -0,0 +1,479 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolationPostgres reports whether err is a Postgres unique-constraint
+// violation (SQLSTATE 23505)
+func uniqueViolationPostgres(err error) bool {
+	var errPq *pq.Error
+	return errors.As(err, &errPq) && errPq.Code == "23505"
+}
+
+// NewImplStorageProductPostgres returns new ImplStorageProductPostgres
+func NewImplStorageProductPostgres(db *sql.DB) *ImplStorageProductPostgres {
+	return &ImplStorageProductPostgres{db: db}
+}
+
+// ImplStorageProductPostgres is an implementation of StorageProduct interface
+type ImplStorageProductPostgres struct {
+	db *sql.DB
+}
+
+// GetOne returns one product by id
+func (impl *ImplStorageProductPostgres) GetOne(id int) (p *Product, err error) {
+	// query
+	query := "SELECT id, name, type, count, price FROM products WHERE id = $1"
+
+	// prepare statement
+	var stmt *sql.Stmt
+	stmt, err = impl.db.Prepare(query)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer stmt.Close()
+
+	// execute query
+	row := stmt.QueryRow(id)
+
+	// scan row
+	var product ProductMySQL
+	err = row.Scan(&product.ID, &product.Name, &product.Type, &product.Count, &product.Price)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = fmt.Errorf("%w. %v", ErrStorageProductNotFound, err)
+		default:
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		}
+		return
+	}
+
+	// serialization
+	p = new(Product)
+	if product.ID.Valid {
+		(*p).ID = int(product.ID.Int32)
+	}
+	if product.Name.Valid {
+		(*p).Name = product.Name.String
+	}
+	if product.Type.Valid {
+		(*p).Type = product.Type.String
+	}
+	if product.Count.Valid {
+		(*p).Count = int(product.Count.Int32)
+	}
+	if product.Price.Valid {
+		(*p).Price = product.Price.Float64
+	}
+
+	return
+}
+
+// buildPostgresSearchQuery builds the count and select queries (and their
+// respective, independently-numbered $N args) for Search from criteria. It
+// is a pure function so the $N numbering can be tested without a database.
+func buildPostgresSearchQuery(criteria SearchCriteria) (query, countQuery string, queryArgs, countArgs []any) {
+	// build WHERE clause shared by the select and the count query
+	var conditions []string
+	var args []any
+	argN := func() string {
+		return "$" + strconv.Itoa(len(args))
+	}
+	if criteria.Name != "" {
+		args = append(args, criteria.Name)
+		conditions = append(conditions, "name = "+argN())
+	}
+	if criteria.Type != "" {
+		args = append(args, criteria.Type)
+		conditions = append(conditions, "type = "+argN())
+	}
+	if criteria.MinPrice != 0 {
+		args = append(args, criteria.MinPrice)
+		conditions = append(conditions, "price >= "+argN())
+	}
+	if criteria.MaxPrice != 0 {
+		args = append(args, criteria.MaxPrice)
+		conditions = append(conditions, "price <= "+argN())
+	}
+	if criteria.MinCount != 0 {
+		args = append(args, criteria.MinCount)
+		conditions = append(conditions, "count >= "+argN())
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// -> count total rows matching the filters, ignoring pagination
+	countQuery = "SELECT COUNT(*) FROM products" + where
+	countArgs = append([]any{}, args...)
+
+	// -> build ORDER BY from the whitelisted sort columns
+	orderBy := ""
+	if criteria.Sort != "" {
+		var orderings []string
+		for _, field := range strings.Split(criteria.Sort, ",") {
+			direction := "ASC"
+			if strings.HasPrefix(field, "-") {
+				direction = "DESC"
+				field = field[1:]
+			}
+
+			column, ok := searchSortColumns[field]
+			if !ok {
+				continue
+			}
+			orderings = append(orderings, column+" "+direction)
+		}
+		if len(orderings) > 0 {
+			orderBy = " ORDER BY " + strings.Join(orderings, ", ")
+		}
+	}
+
+	// -> pagination
+	limitClause := ""
+	if criteria.Limit > 0 {
+		args = append(args, criteria.Limit)
+		limitArg := argN()
+		args = append(args, criteria.Offset)
+		offsetArg := argN()
+		limitClause = " LIMIT " + limitArg + " OFFSET " + offsetArg
+	}
+
+	query = "SELECT id, name, type, count, price FROM products" + where + orderBy + limitClause
+	queryArgs = args
+
+	return
+}
+
+// Search returns products matching criteria, along with the total count
+// ignoring pagination
+func (impl *ImplStorageProductPostgres) Search(criteria SearchCriteria) (p []*Product, total int, err error) {
+	query, countQuery, queryArgs, countArgs := buildPostgresSearchQuery(criteria)
+
+	// -> count total rows matching the filters, ignoring pagination
+	var countStmt *sql.Stmt
+	countStmt, err = impl.db.Prepare(countQuery)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer countStmt.Close()
+
+	err = countStmt.QueryRow(countArgs...).Scan(&total)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+
+	// prepare statement
+	var stmt *sql.Stmt
+	stmt, err = impl.db.Prepare(query)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer stmt.Close()
+
+	// execute query
+	var rows *sql.Rows
+	rows, err = stmt.Query(queryArgs...)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer rows.Close()
+
+	// scan rows
+	for rows.Next() {
+		var product ProductMySQL
+		err = rows.Scan(&product.ID, &product.Name, &product.Type, &product.Count, &product.Price)
+		if err != nil {
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+			return
+		}
+
+		// serialization
+		pr := new(Product)
+		if product.ID.Valid {
+			(*pr).ID = int(product.ID.Int32)
+		}
+		if product.Name.Valid {
+			(*pr).Name = product.Name.String
+		}
+		if product.Type.Valid {
+			(*pr).Type = product.Type.String
+		}
+		if product.Count.Valid {
+			(*pr).Count = int(product.Count.Int32)
+		}
+		if product.Price.Valid {
+			(*pr).Price = product.Price.Float64
+		}
+
+		p = append(p, pr)
+	}
+	if err = rows.Err(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+
+	return
+}
+
+// Store stores product
+func (impl *ImplStorageProductPostgres) Store(p *Product) (err error) {
+	// deserialize
+	var product ProductMySQL
+	if (*p).Name != "" {
+		product.Name.Valid = true
+		product.Name.String = (*p).Name
+	}
+	if (*p).Type != "" {
+		product.Type.Valid = true
+		product.Type.String = (*p).Type
+	}
+	if (*p).Count != 0 {
+		product.Count.Valid = true
+		product.Count.Int32 = int32((*p).Count)
+	}
+	if (*p).Price != 0 {
+		product.Price.Valid = true
+		product.Price.Float64 = (*p).Price
+	}
+
+	// query
+	query := "INSERT INTO products (name, type, count, price) VALUES ($1, $2, $3, $4) RETURNING id"
+
+	// prepare statement
+	var stmt *sql.Stmt
+	stmt, err = impl.db.Prepare(query)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer stmt.Close()
+
+	// execute query
+	var lastInsertID int
+	err = stmt.QueryRow(product.Name, product.Type, product.Count, product.Price).Scan(&lastInsertID)
+	if err != nil {
+		if uniqueViolationPostgres(err) {
+			err = fmt.Errorf("%w. %v", ErrStorageProductNotUnique, err)
+		} else {
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		}
+		return
+	}
+
+	(*p).ID = lastInsertID
+
+	return
+}
+
+// Update updates product
+func (impl *ImplStorageProductPostgres) Update(p *Product) (err error) {
+	// deserialize
+	var product ProductMySQL
+	if (*p).Name != "" {
+		product.Name.Valid = true
+		product.Name.String = (*p).Name
+	}
+	if (*p).Type != "" {
+		product.Type.Valid = true
+		product.Type.String = (*p).Type
+	}
+	if (*p).Count != 0 {
+		product.Count.Valid = true
+		product.Count.Int32 = int32((*p).Count)
+	}
+	if (*p).Price != 0 {
+		product.Price.Valid = true
+		product.Price.Float64 = (*p).Price
+	}
+
+	// query
+	query := "UPDATE products SET name = $1, type = $2, count = $3, price = $4 WHERE id = $5"
+
+	// prepare statement
+	var stmt *sql.Stmt
+	stmt, err = impl.db.Prepare(query)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer stmt.Close()
+
+	// execute query
+	result, err := stmt.Exec(product.Name, product.Type, product.Count, product.Price, (*p).ID)
+	if err != nil {
+		if uniqueViolationPostgres(err) {
+			err = fmt.Errorf("%w. %v", ErrStorageProductNotUnique, err)
+		} else {
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		}
+		return
+	}
+
+	// check rows affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+
+	if rowsAffected == 0 {
+		err = fmt.Errorf("%w. %s", ErrStorageProductNotFound, "no rows affected")
+		return
+	}
+
+	return
+}
+
+// Patch applies a sparse update to a product, leaving nil fields in patch
+// untouched
+func (impl *ImplStorageProductPostgres) Patch(id int, patch ProductPatch) (p *Product, err error) {
+	// query
+	query := "UPDATE products SET name = COALESCE($1, name), type = COALESCE($2, type), count = COALESCE($3, count), price = COALESCE($4, price) WHERE id = $5"
+
+	// prepare statement
+	var stmt *sql.Stmt
+	stmt, err = impl.db.Prepare(query)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer stmt.Close()
+
+	// execute query
+	result, err := stmt.Exec(nullString(patch.Name), nullString(patch.Type), nullInt32(patch.Count), nullFloat64(patch.Price), id)
+	if err != nil {
+		if uniqueViolationPostgres(err) {
+			err = fmt.Errorf("%w. %v", ErrStorageProductNotUnique, err)
+		} else {
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		}
+		return
+	}
+
+	// check rows affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	if rowsAffected == 0 {
+		err = fmt.Errorf("%w. %s", ErrStorageProductNotFound, "no rows affected")
+		return
+	}
+
+	return impl.GetOne(id)
+}
+
+// Buy decrements a product's stock by qty, failing if there isn't enough of
+// it left
+func (impl *ImplStorageProductPostgres) Buy(id int, qty int) (p *Product, err error) {
+	// begin transaction
+	var tx *sql.Tx
+	tx, err = impl.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer tx.Rollback()
+
+	// -> lock the row for the duration of the transaction
+	var product ProductMySQL
+	row := tx.QueryRow("SELECT id, name, type, count, price FROM products WHERE id = $1 FOR UPDATE", id)
+	err = row.Scan(&product.ID, &product.Name, &product.Type, &product.Count, &product.Price)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = fmt.Errorf("%w. %v", ErrStorageProductNotFound, err)
+		default:
+			err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		}
+		return
+	}
+
+	currentCount := int(product.Count.Int32)
+	if currentCount < qty {
+		err = fmt.Errorf("%w. have %d, want %d", ErrStorageProductInsufficientStock, currentCount, qty)
+		return
+	}
+
+	// -> decrement stock
+	_, err = tx.Exec("UPDATE products SET count = count - $1 WHERE id = $2", qty, id)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+
+	// serialization
+	p = new(Product)
+	if product.Name.Valid {
+		(*p).Name = product.Name.String
+	}
+	if product.Type.Valid {
+		(*p).Type = product.Type.String
+	}
+	if product.Price.Valid {
+		(*p).Price = product.Price.Float64
+	}
+	(*p).ID = id
+	(*p).Count = currentCount - qty
+
+	return
+}
+
+// Delete deletes product by id
+func (impl *ImplStorageProductPostgres) Delete(id int) (err error) {
+	// query
+	query := "DELETE FROM products WHERE id = $1"
+
+	// prepare statement
+	var stmt *sql.Stmt
+	stmt, err = impl.db.Prepare(query)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+	defer stmt.Close()
+
+	// execute query
+	result, err := stmt.Exec(id)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+
+	// check rows affected
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrStorageProductInternal, err)
+		return
+	}
+
+	if rowsAffected != 1 {
+		err = fmt.Errorf("%w. %s", ErrStorageProductInternal, "rows affected != 1")
+		return
+	}
+
+	return
+}
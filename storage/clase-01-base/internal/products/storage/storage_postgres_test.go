@@ -0,0 +1,42 @@
+package storage
+
+import "testing"
+
+func TestBuildPostgresSearchQueryPlaceholders(t *testing.T) {
+	criteria := SearchCriteria{
+		Name:  "keyboard",
+		Type:  "peripheral",
+		Limit: 20,
+	}
+
+	query, countQuery, queryArgs, countArgs := buildPostgresSearchQuery(criteria)
+
+	wantQuery := "SELECT id, name, type, count, price FROM products WHERE name = $1 AND type = $2 LIMIT $3 OFFSET $4"
+	if query != wantQuery {
+		t.Fatalf("query = %q, want %q", query, wantQuery)
+	}
+	wantCountQuery := "SELECT COUNT(*) FROM products WHERE name = $1 AND type = $2"
+	if countQuery != wantCountQuery {
+		t.Fatalf("countQuery = %q, want %q", countQuery, wantCountQuery)
+	}
+
+	wantQueryArgs := []any{"keyboard", "peripheral", 20, 0}
+	if len(queryArgs) != len(wantQueryArgs) {
+		t.Fatalf("queryArgs = %v, want %v", queryArgs, wantQueryArgs)
+	}
+	for i, want := range wantQueryArgs {
+		if queryArgs[i] != want {
+			t.Fatalf("queryArgs[%d] = %v, want %v (this is the $%d placeholder)", i, queryArgs[i], want, i+1)
+		}
+	}
+
+	wantCountArgs := []any{"keyboard", "peripheral"}
+	if len(countArgs) != len(wantCountArgs) {
+		t.Fatalf("countArgs = %v, want %v", countArgs, wantCountArgs)
+	}
+	for i, want := range wantCountArgs {
+		if countArgs[i] != want {
+			t.Fatalf("countArgs[%d] = %v, want %v", i, countArgs[i], want)
+		}
+	}
+}